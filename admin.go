@@ -0,0 +1,283 @@
+package licensing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/docker/libtrust"
+	"github.com/docker/licensing/lib/errors"
+	"github.com/docker/licensing/lib/go-clientlib"
+	"github.com/docker/licensing/model"
+)
+
+// licensesPath is the REST endpoint a self-hosted licensing Server
+// exposes, and that the Client talks to when not using the Docker Hub
+// backend.
+const licensesPath = "/api/billing/v4/licenses"
+
+// IssueLicense asks the licensing backend at c.baseURI to issue a new
+// license for req. It is the client-side counterpart of Server.IssueLicense,
+// for operators running their own licensing endpoint with this module.
+func (c *client) IssueLicense(ctx context.Context, req *model.LicenseIssueRequest) (license *model.IssuedLicense, err error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal license issue request")
+	}
+
+	u := c.baseURI
+	u.Path = licensesPath
+
+	license = &model.IssuedLicense{}
+	_, _, err = c.doReq(ctx, http.MethodPost, &u,
+		clientlib.JSONRequest(body),
+		clientlib.JSONResponse(license),
+	)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to issue license")
+	}
+
+	return license, nil
+}
+
+// ListLicenses lists the licenses known to the licensing backend at
+// c.baseURI, narrowed by filter.
+func (c *client) ListLicenses(ctx context.Context, filter model.LicenseFilter) (licenses []*model.IssuedLicense, err error) {
+	u := c.baseURI
+	u.Path = licensesPath
+	u.RawQuery = filterQuery(filter).Encode()
+
+	var resp []*model.IssuedLicense
+	_, _, err = c.doReq(ctx, http.MethodGet, &u, clientlib.JSONResponse(&resp))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list licenses")
+	}
+
+	return resp, nil
+}
+
+// DeleteLicense deletes the license identified by id from the licensing
+// backend at c.baseURI.
+func (c *client) DeleteLicense(ctx context.Context, id string) error {
+	u := c.baseURI
+	u.Path = licensesPath + "/" + url.PathEscape(id)
+
+	_, _, err := c.doReq(ctx, http.MethodDelete, &u)
+	if err != nil {
+		return errors.Wrap(err, errors.Fields{
+			"id": id,
+		})
+	}
+
+	return nil
+}
+
+func filterQuery(filter model.LicenseFilter) url.Values {
+	q := url.Values{}
+	if filter.DockerID != "" {
+		q.Set("docker_id", filter.DockerID)
+	}
+	if filter.OrgID != "" {
+		q.Set("org_id", filter.OrgID)
+	}
+	if filter.Expired != nil {
+		q.Set("expired", fmt.Sprintf("%t", *filter.Expired))
+	}
+
+	return q
+}
+
+// LicenseRepository is the storage interface a self-hosted Server needs in
+// order to persist issued licenses and enforce uniqueness on the encoded
+// JWS. Implementations should report duplicate inserts as a conflict via
+// errors.HTTPStatus so that Server can translate it into the right
+// response code.
+type LicenseRepository interface {
+	Insert(ctx context.Context, license *model.IssuedLicense) error
+	List(ctx context.Context, filter model.LicenseFilter) ([]*model.IssuedLicense, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// Server implements the admin side of the licensing REST API: issuing,
+// listing and deleting licenses. It is meant to be wired into an
+// operator's own HTTP server via ServeHTTP, for those who don't use the
+// Docker Hub backend.
+type Server struct {
+	privateKey libtrust.PrivateKey
+	repo       LicenseRepository
+}
+
+// NewServer creates a Server that signs issued licenses with the private
+// key in config and persists them with repo.
+func NewServer(config *Config, repo LicenseRepository) (*Server, error) {
+	privateKey, err := unmarshalPrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		privateKey: privateKey,
+		repo:       repo,
+	}, nil
+}
+
+func unmarshalPrivateKey(encoded string) (libtrust.PrivateKey, error) {
+	pemBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.Fields{
+			"private_key": encoded,
+		}, "decode private key failed")
+	}
+
+	key, err := libtrust.UnmarshalPrivateKeyPEM(pemBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, errors.Fields{
+			"private_key": encoded,
+		}, "unmarshal private key failed")
+	}
+
+	return key, nil
+}
+
+// IssueLicense signs req's claims with s.privateKey and persists the
+// result via s.repo. It does no duplicate check of its own: it returns
+// whatever error s.repo.Insert reports, such as a conflict for a JWS that
+// has already been issued.
+func (s *Server) IssueLicense(ctx context.Context, req *model.LicenseIssueRequest) (*model.IssuedLicense, error) {
+	claims, err := json.Marshal(jwsPayload{
+		Expiration:      req.Expiration,
+		ScanningEnabled: req.ScanningEnabled,
+		LicenseType:     req.LicenseType,
+		Tier:            req.Tier,
+		MaxEngines:      req.MaxEngines,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal license claims")
+	}
+
+	sig, err := libtrust.NewJSONSignature(claims)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to build license signature")
+	}
+	if err := sig.Sign(s.privateKey); err != nil {
+		return nil, errors.WithMessage(err, "failed to sign license")
+	}
+
+	authorization, err := sig.PrettySignature("signatures")
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to encode license signature")
+	}
+
+	license := &model.IssuedLicense{
+		ID:            fmt.Sprintf("%s-%d", req.DockerID, req.Expiration),
+		Authorization: string(authorization),
+		Subscription: model.SubscriptionDetail{
+			DockerID:   req.DockerID,
+			ProductID:  req.ProductID,
+			State:      "active",
+			Expiration: req.Expiration,
+			Tier:       req.Tier,
+			Quantity:   req.MaxEngines,
+		},
+	}
+
+	if err := s.repo.Insert(ctx, license); err != nil {
+		return nil, errors.Wrap(err, errors.Fields{
+			"dockerID": req.DockerID,
+		})
+	}
+
+	return license, nil
+}
+
+// ListLicenses returns the licenses in s.repo matching filter.
+func (s *Server) ListLicenses(ctx context.Context, filter model.LicenseFilter) ([]*model.IssuedLicense, error) {
+	licenses, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list licenses")
+	}
+
+	return licenses, nil
+}
+
+// DeleteLicense removes the license identified by id from s.repo.
+func (s *Server) DeleteLicense(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return errors.Wrap(err, errors.Fields{
+			"id": id,
+		})
+	}
+
+	return nil
+}
+
+// ServeHTTP wires up POST/GET/DELETE on licensesPath to s.IssueLicense,
+// s.ListLicenses and s.DeleteLicense respectively.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == licensesPath:
+		var req model.LicenseIssueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		license, err := s.IssueLicense(ctx, &req)
+		if err != nil {
+			writeServerError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, license)
+
+	case r.Method == http.MethodGet && r.URL.Path == licensesPath:
+		filter := model.LicenseFilter{
+			DockerID: r.URL.Query().Get("docker_id"),
+			OrgID:    r.URL.Query().Get("org_id"),
+		}
+		if expired, err := strconv.ParseBool(r.URL.Query().Get("expired")); err == nil {
+			filter.Expired = &expired
+		}
+
+		licenses, err := s.ListLicenses(ctx, filter)
+		if err != nil {
+			writeServerError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, licenses)
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, licensesPath+"/"):
+		id := strings.TrimPrefix(r.URL.Path, licensesPath+"/")
+		if err := s.DeleteLicense(ctx, id); err != nil {
+			writeServerError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeServerError(w http.ResponseWriter, err error) {
+	code, ok := errors.HTTPStatus(err)
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	http.Error(w, err.Error(), code)
+}
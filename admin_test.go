@@ -0,0 +1,116 @@
+package licensing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/docker/licensing/model"
+)
+
+func TestFilterQuery(t *testing.T) {
+	expired := true
+
+	q := filterQuery(model.LicenseFilter{
+		DockerID: "docker-id",
+		OrgID:    "org-id",
+		Expired:  &expired,
+	})
+
+	want := url.Values{
+		"docker_id": []string{"docker-id"},
+		"org_id":    []string{"org-id"},
+		"expired":   []string{"true"},
+	}
+	if q.Encode() != want.Encode() {
+		t.Fatalf("filterQuery() = %v, want %v", q, want)
+	}
+
+	if empty := filterQuery(model.LicenseFilter{}); len(empty) != 0 {
+		t.Fatalf("filterQuery(zero value) = %v, want empty", empty)
+	}
+}
+
+// recordingRepo wraps memRepo and remembers the last filter passed to
+// List, so tests can assert on what ServeHTTP derived from the request.
+type recordingRepo struct {
+	*memRepo
+	lastFilter model.LicenseFilter
+}
+
+func (r *recordingRepo) List(ctx context.Context, filter model.LicenseFilter) ([]*model.IssuedLicense, error) {
+	r.lastFilter = filter
+	return r.memRepo.List(ctx, filter)
+}
+
+func TestServeHTTPIssueListDelete(t *testing.T) {
+	repo := &recordingRepo{memRepo: newMemRepo()}
+	srv, c := newTestServerAndClient(t, repo)
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	tsURL, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	c.(*client).baseURI = *tsURL
+
+	issued, err := c.IssueLicense(context.Background(), &model.LicenseIssueRequest{
+		DockerID:   "docker-id",
+		ProductID:  "docker-ee-standard",
+		Expiration: time.Now().Add(24 * time.Hour).Unix(),
+		Tier:       "standard",
+		MaxEngines: 10,
+	})
+	if err != nil {
+		t.Fatalf("IssueLicense: %v", err)
+	}
+	if issued.Subscription.Tier != "standard" || issued.Subscription.Quantity != 10 {
+		t.Fatalf("issued license missing tier/quantity: %+v", issued.Subscription)
+	}
+
+	expired := false
+	licenses, err := c.ListLicenses(context.Background(), model.LicenseFilter{DockerID: "docker-id", Expired: &expired})
+	if err != nil {
+		t.Fatalf("ListLicenses: %v", err)
+	}
+	if len(licenses) != 1 || licenses[0].ID != issued.ID {
+		t.Fatalf("ListLicenses() = %+v, want [%+v]", licenses, issued)
+	}
+	if repo.lastFilter.Expired == nil || *repo.lastFilter.Expired != false {
+		t.Fatalf("expected ServeHTTP to parse expired=false query param, got %+v", repo.lastFilter)
+	}
+
+	if err := c.DeleteLicense(context.Background(), issued.ID); err != nil {
+		t.Fatalf("DeleteLicense: %v", err)
+	}
+
+	licenses, err = c.ListLicenses(context.Background(), model.LicenseFilter{DockerID: "docker-id"})
+	if err != nil {
+		t.Fatalf("ListLicenses after delete: %v", err)
+	}
+	if len(licenses) != 0 {
+		t.Fatalf("expected license to be deleted, got %+v", licenses)
+	}
+}
+
+// TestServeHTTPRejectsUnrelatedDeletePaths guards against matching DELETE
+// requests whose path merely happens to be longer than licensesPath,
+// rather than actually living under it.
+func TestServeHTTPRejectsUnrelatedDeletePaths(t *testing.T) {
+	repo := &recordingRepo{memRepo: newMemRepo()}
+	srv, _ := newTestServerAndClient(t, repo)
+
+	req := httptest.NewRequest(http.MethodDelete, "/completely/unrelated/route/zzzz", nil)
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unrelated DELETE path, got %d", rec.Code)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/docker/libtrust"
 	"github.com/docker/licensing/lib/errors"
@@ -29,6 +28,13 @@ type Client interface {
 	ListSubscriptions(ctx context.Context, authToken, dockerID string) (response []*model.SubscriptionDetail, err error)
 	DownloadLicenseFromHub(ctx context.Context, authToken, subscriptionID string) (license *model.IssuedLicense, err error)
 	ParseLicense(license []byte) (parsedLicense *model.IssuedLicense, err error)
+	SummarizeLicense(license *model.IssuedLicense) (subscriptionDetail *model.SubscriptionDetail, err error)
+	VerifyLicenseOffline(license model.IssuedLicense) (res *model.CheckResponse, err error)
+	IssueLicense(ctx context.Context, req *model.LicenseIssueRequest) (license *model.IssuedLicense, err error)
+	ListLicenses(ctx context.Context, filter model.LicenseFilter) (licenses []*model.IssuedLicense, err error)
+	DeleteLicense(ctx context.Context, id string) (err error)
+	SubscriptionsIterator(ctx context.Context, authToken, dockerID, productPrefix string) *SubscriptionIterator
+	OrgsIterator(ctx context.Context, authToken string) *OrgIterator
 }
 
 func (c *client) LoginViaAuth(ctx context.Context, username, password string) (authToken string, err error) {
@@ -42,11 +48,15 @@ func (c *client) LoginViaAuth(ctx context.Context, username, password string) (a
 	return creds.Token, nil
 }
 
+// GetHubUserOrgs returns every org the authenticated user belongs to. For
+// large result sets, prefer OrgsIterator, which pages lazily instead of
+// buffering everything in memory.
 func (c *client) GetHubUserOrgs(ctx context.Context, authToken string) (orgs []model.Org, err error) {
-	ctx = jwt.NewContext(ctx, authToken)
-
-	orgs, err = c.getUserOrgs(ctx, model.PaginationParams{})
-	if err != nil {
+	it := c.OrgsIterator(ctx, authToken)
+	for it.Next() {
+		orgs = append(orgs, it.Value())
+	}
+	if err := it.Err(); err != nil {
 		return nil, errors.WithMessage(err, "Failed to get orgs for user")
 	}
 
@@ -118,26 +128,23 @@ func (c *client) GenerateNewTrialSubscription(ctx context.Context, authToken, do
 	return sub.ID, nil
 }
 
+// ListSubscriptions returns every docker-ee subscription belonging to
+// dockerID. For large orgs, prefer SubscriptionsIterator, which pages
+// lazily and lets the server apply the product_prefix filter instead of
+// filtering the full result set client-side.
 func (c *client) ListSubscriptions(ctx context.Context, authToken, dockerID string) (response []*model.SubscriptionDetail, err error) {
-	ctx = jwt.NewContext(ctx, authToken)
+	dockerSubs := []*model.SubscriptionDetail{}
 
-	subs, err := c.listSubscriptions(ctx, map[string]string{"docker_id": dockerID})
-	if err != nil {
+	it := c.SubscriptionsIterator(ctx, authToken, dockerID, "docker-ee")
+	for it.Next() {
+		dockerSubs = append(dockerSubs, it.Value())
+	}
+	if err := it.Err(); err != nil {
 		return nil, errors.Wrap(err, errors.Fields{
 			"dockerID": dockerID,
 		})
 	}
 
-	// filter out non docker licenses
-	dockerSubs := []*model.SubscriptionDetail{}
-	for _, sub := range subs {
-		if !strings.HasPrefix(sub.ProductID, "docker-ee") {
-			continue
-		}
-
-		dockerSubs = append(dockerSubs, sub)
-	}
-
 	return dockerSubs, nil
 }
 
@@ -165,9 +172,10 @@ func (c *client) ParseLicense(license []byte) (parsedLicense *model.IssuedLicens
 }
 
 type client struct {
-	publicKey libtrust.PublicKey
-	hclient   *http.Client
-	baseURI   url.URL
+	publicKey    libtrust.PublicKey
+	hclient      *http.Client
+	baseURI      url.URL
+	errorSummary func(body []byte) string
 }
 
 // Config holds licensing client configuration
@@ -176,6 +184,15 @@ type Config struct {
 	HTTPClient *http.Client
 	// used by licensing client to validate an issued license
 	PublicKey string
+	// used by a self-hosted licensing Server to sign issued licenses
+	PrivateKey string
+	// ErrorSummary extracts a human-readable message out of an error
+	// response body. Defaults to errorSummary.
+	ErrorSummary func(body []byte) string
+	// Middleware wraps the HTTP transport used for every request, applied
+	// in order (Middleware[0] sees the request first). Use WithRetry,
+	// WithRateLimit and WithLogging for the common cases.
+	Middleware []func(http.RoundTripper) http.RoundTripper
 }
 
 func errorSummary(body []byte) string {
@@ -202,11 +219,29 @@ func New(config *Config) (Client, error) {
 	if hclient == nil {
 		hclient = &http.Client{}
 	}
+	if len(config.Middleware) > 0 {
+		base := hclient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+
+		// copy the client so we don't mutate one the caller still holds a
+		// reference to.
+		wrapped := *hclient
+		wrapped.Transport = chainMiddleware(base, config.Middleware)
+		hclient = &wrapped
+	}
+
+	summary := config.ErrorSummary
+	if summary == nil {
+		summary = errorSummary
+	}
 
 	return &client{
-		baseURI:   config.BaseURI,
-		hclient:   hclient,
-		publicKey: publicKey,
+		baseURI:      config.BaseURI,
+		hclient:      hclient,
+		publicKey:    publicKey,
+		errorSummary: summary,
 	}, nil
 }
 
@@ -240,7 +275,7 @@ func (c *client) requestDefaults() []clientlib.RequestOption {
 		func(req *clientlib.Request) {
 			tok, _ := jwt.FromContext(req.Context())
 			req.Header.Add("Authorization", "Bearer "+tok)
-			req.ErrorSummary = errorSummary
+			req.ErrorSummary = c.errorSummary
 			req.Client = c.hclient
 		},
 	}
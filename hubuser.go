@@ -0,0 +1,114 @@
+package licensing
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/licensing/lib/errors"
+	"github.com/docker/licensing/model"
+)
+
+// LicenseDisplay is a flattened, display-ready view of a subscription,
+// merging the subscription's billing data with the name of whoever owns
+// it (the user themselves, or one of their orgs).
+type LicenseDisplay struct {
+	Owner      string
+	Expiration int64
+	Tier       string
+	Quantity   int
+	State      string
+}
+
+// HubUser wraps a Client with an authenticated session, so that license
+// lookups can be scoped to the logged-in user and the orgs they belong to
+// without every caller having to thread the auth token and org list
+// through themselves.
+type HubUser struct {
+	client Client
+	Token  string
+	User   model.User
+	Orgs   []model.Org
+}
+
+// Login authenticates against the Hub via c and returns a HubUser
+// populated with the user's profile and the orgs they belong to.
+func Login(ctx context.Context, c Client, username, password string) (*HubUser, error) {
+	token, err := c.LoginViaAuth(ctx, username, password)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to log in")
+	}
+
+	user, err := c.GetHubUserByName(ctx, username)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get user")
+	}
+
+	orgs, err := c.GetHubUserOrgs(ctx, token)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get user orgs")
+	}
+
+	return &HubUser{
+		client: c,
+		Token:  token,
+		User:   *user,
+		Orgs:   orgs,
+	}, nil
+}
+
+// GetOrgByID returns the org in u.Orgs whose ID matches id.
+func (u *HubUser) GetOrgByID(id string) (model.Org, error) {
+	for _, org := range u.Orgs {
+		if org.ID == id {
+			return org, nil
+		}
+	}
+
+	return model.Org{}, errors.New("org not found")
+}
+
+// GetAvailableLicenses returns every subscription visible to u, merging
+// the user's own subscriptions with those of every org they belong to,
+// sorted by owner name.
+func (u *HubUser) GetAvailableLicenses(ctx context.Context) ([]LicenseDisplay, error) {
+	var licenses []LicenseDisplay
+
+	ownSubs, err := u.client.ListSubscriptions(ctx, u.Token, u.User.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Fields{
+			"dockerID": u.User.ID,
+		})
+	}
+	licenses = append(licenses, toLicenseDisplays(u.User.Username, ownSubs)...)
+
+	for _, org := range u.Orgs {
+		orgSubs, err := u.client.ListSubscriptions(ctx, u.Token, org.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Fields{
+				"dockerID": org.ID,
+			})
+		}
+		licenses = append(licenses, toLicenseDisplays(org.Orgname, orgSubs)...)
+	}
+
+	sort.Slice(licenses, func(i, j int) bool {
+		return licenses[i].Owner < licenses[j].Owner
+	})
+
+	return licenses, nil
+}
+
+func toLicenseDisplays(owner string, subs []*model.SubscriptionDetail) []LicenseDisplay {
+	displays := make([]LicenseDisplay, 0, len(subs))
+	for _, sub := range subs {
+		displays = append(displays, LicenseDisplay{
+			Owner:      owner,
+			Expiration: sub.Expiration,
+			Tier:       sub.Tier,
+			Quantity:   sub.Quantity,
+			State:      sub.State,
+		})
+	}
+
+	return displays
+}
@@ -0,0 +1,178 @@
+// Package licensestore persists and retrieves IssuedLicenses against a
+// running Docker engine, verifying them against a licensing.Client's
+// public key on the way back out, so that license-aware consumers don't
+// need to reimplement the swarm-vs-standalone storage conventions or
+// key verification themselves.
+package licensestore
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/licensing"
+	"github.com/docker/licensing/lib/errors"
+	"github.com/docker/licensing/model"
+)
+
+// licenseConfigName is the name under which the license is stored as a
+// swarm config object.
+const licenseConfigName = "com.docker.license"
+
+// licensesSubDir is where the license is persisted for non-swarm engines,
+// relative to the engine's root dir (typically /var/lib/docker).
+const licensesSubDir = "licenses"
+
+// WrappedDockerClient is the subset of the Docker engine API client that
+// licensestore needs in order to store and retrieve licenses, either via
+// swarm configs or via the engine's info endpoint.
+type WrappedDockerClient interface {
+	ConfigCreate(ctx context.Context, config swarm.ConfigSpec) (types.ConfigCreateResponse, error)
+	ConfigList(ctx context.Context, options types.ConfigListOptions) ([]swarm.Config, error)
+	ConfigRemove(ctx context.Context, id string) error
+	Info(ctx context.Context) (types.Info, error)
+}
+
+// StoreLicense persists license so that it can later be retrieved with
+// LoadLocalLicense. If dclnt is connected to a swarm manager, the license
+// is stored as a swarm config; otherwise it is written under rootDir.
+func StoreLicense(ctx context.Context, dclnt WrappedDockerClient, license *model.IssuedLicense, rootDir string) error {
+	raw, err := json.Marshal(license)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal license")
+	}
+
+	info, err := dclnt.Info(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get engine info")
+	}
+
+	if info.Swarm.ControlAvailable {
+		if err := removeExistingConfig(ctx, dclnt); err != nil {
+			return err
+		}
+
+		_, err = dclnt.ConfigCreate(ctx, swarm.ConfigSpec{
+			Annotations: swarm.Annotations{
+				Name: licenseConfigName,
+			},
+			Data: raw,
+		})
+		if err != nil {
+			return errors.WithMessage(err, "failed to store license as swarm config")
+		}
+
+		return nil
+	}
+
+	return storeLicenseLocally(raw, rootDir)
+}
+
+// LoadLocalLicense retrieves whichever license was previously stored with
+// StoreLicense and verifies it against c's public key before returning it.
+// When dclnt is a swarm manager only the swarm config is read; otherwise
+// the file under the engine's DockerRootDir is read.
+func LoadLocalLicense(ctx context.Context, dclnt WrappedDockerClient, c licensing.Client) (*model.IssuedLicense, error) {
+	info, err := dclnt.Info(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get engine info")
+	}
+
+	var raw []byte
+	if info.Swarm.ControlAvailable {
+		configs, err := dclnt.ConfigList(ctx, types.ConfigListOptions{})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to list swarm configs")
+		}
+
+		for _, cfg := range configs {
+			if cfg.Spec.Annotations.Name == licenseConfigName {
+				raw = cfg.Spec.Data
+				break
+			}
+		}
+
+		if raw == nil {
+			return nil, errors.New("no license found in swarm configs")
+		}
+	} else {
+		raw, err = loadLicenseLocally(info.DockerRootDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	license := &model.IssuedLicense{}
+	if err := json.Unmarshal(raw, license); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse stored license")
+	}
+
+	if _, err := c.VerifyLicenseOffline(*license); err != nil {
+		return nil, errors.WithMessage(err, "stored license failed verification")
+	}
+
+	return license, nil
+}
+
+// ListLocalLicenses returns the license known to dclnt as a one-element
+// slice. Only one license is ever stored at a time (StoreLicense replaces
+// the existing swarm config/file rather than adding to it), so the slice
+// never holds more than one element; it exists for callers that expect a
+// list (mirroring the Hub-backed multi-subscription APIs). When no license
+// is stored, it returns LoadLocalLicense's error, not an empty slice.
+func ListLocalLicenses(ctx context.Context, dclnt WrappedDockerClient, c licensing.Client) ([]*model.IssuedLicense, error) {
+	license, err := LoadLocalLicense(ctx, dclnt, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*model.IssuedLicense{license}, nil
+}
+
+func removeExistingConfig(ctx context.Context, dclnt WrappedDockerClient) error {
+	configs, err := dclnt.ConfigList(ctx, types.ConfigListOptions{})
+	if err != nil {
+		return errors.WithMessage(err, "failed to list swarm configs")
+	}
+
+	for _, cfg := range configs {
+		if cfg.Spec.Annotations.Name == licenseConfigName {
+			if err := dclnt.ConfigRemove(ctx, cfg.ID); err != nil {
+				return errors.WithMessage(err, "failed to remove existing license config")
+			}
+		}
+	}
+
+	return nil
+}
+
+func storeLicenseLocally(raw []byte, rootDir string) error {
+	dir := filepath.Join(rootDir, licensesSubDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.WithMessage(err, "failed to create licenses dir")
+	}
+
+	path := filepath.Join(dir, licenseConfigName)
+	if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+		return errors.WithMessage(err, "failed to write license file")
+	}
+
+	return nil
+}
+
+func loadLicenseLocally(rootDir string) ([]byte, error) {
+	path := filepath.Join(rootDir, licensesSubDir, licenseConfigName)
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no license found")
+		}
+		return nil, errors.WithMessage(err, "failed to read license file")
+	}
+
+	return raw, nil
+}
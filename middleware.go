@@ -0,0 +1,241 @@
+package licensing
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Logger is the structured logging interface used by WithLogging. It is
+// satisfied by *log.Logger (via a small adapter) as well as most
+// structured loggers in use downstream.
+type Logger interface {
+	Logf(format string, args ...interface{})
+}
+
+// maxRetries bounds the number of retry attempts WithRetry will make
+// before giving up and returning the last response/error seen.
+const maxRetries = 5
+
+// WithRetry returns middleware that retries requests that fail with a 5xx
+// or 429 response, using exponential backoff with jitter. A 429 or 503
+// response's Retry-After header, if present, takes precedence over the
+// computed backoff.
+func WithRetry() func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &retryTransport{next: next}
+	}
+}
+
+type retryTransport struct {
+	next http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.Body != nil {
+				// req.Body was already drained by the previous attempt; only
+				// retry if it can be replayed.
+				if req.GetBody == nil {
+					return resp, err
+				}
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					return nil, bodyErr
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err = t.next.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		if attempt == maxRetries {
+			return resp, nil
+		}
+
+		// a drained body without GetBody can't be replayed, so hand back
+		// this response unclosed instead of retrying.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait := retryAfter(resp)
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+
+	return resp, err
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+
+	return time.Duration(secs) * time.Second
+}
+
+func backoff(attempt int) time.Duration {
+	base := time.Duration(math.Pow(2, float64(attempt))) * 100 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base) + 1))
+	return base + jitter
+}
+
+// WithRateLimit returns middleware that throttles outgoing requests to rps
+// requests per second per host, allowing bursts of up to burst requests.
+func WithRateLimit(rps float64, burst int) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &rateLimitTransport{
+			next:    next,
+			rps:     rps,
+			burst:   burst,
+			buckets: map[string]*tokenBucket{},
+		}
+	}
+}
+
+type rateLimitTransport struct {
+	next  http.RoundTripper
+	rps   float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	bucket := t.bucketFor(req.URL.Host)
+
+	if err := bucket.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+func (t *rateLimitTransport) bucketFor(host string) *tokenBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket, ok := t.buckets[host]
+	if !ok {
+		bucket = newTokenBucket(t.rps, t.burst)
+		t.buckets[host] = bucket
+	}
+
+	return bucket
+}
+
+// tokenBucket is a minimal token-bucket rate limiter, used instead of
+// pulling in golang.org/x/time/rate so this module has no extra
+// dependencies beyond what it already needs.
+type tokenBucket struct {
+	rps   float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rps:    rps,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rps)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rps * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// WithLogging returns middleware that logs each outgoing request and its
+// resulting status code (or error) via logger.
+func WithLogging(logger Logger) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &loggingTransport{next: next, logger: logger}
+	}
+}
+
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger Logger
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.logger.Logf("licensing: %s %s failed after %s: %v", req.Method, req.URL, elapsed, err)
+		return nil, err
+	}
+
+	t.logger.Logf("licensing: %s %s -> %d in %s", req.Method, req.URL, resp.StatusCode, elapsed)
+
+	return resp, nil
+}
+
+// chainMiddleware wraps base with each middleware in middlewares, in
+// order, so that middlewares[0] sees the request first.
+func chainMiddleware(base http.RoundTripper, middlewares []func(http.RoundTripper) http.RoundTripper) http.RoundTripper {
+	rt := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+
+	return rt
+}
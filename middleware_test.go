@@ -0,0 +1,62 @@
+package licensing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// TestRetryTransportReplaysBody verifies that a retried request resends
+// its original body instead of an empty one.
+func TestRetryTransportReplaysBody(t *testing.T) {
+	var bodies []string
+
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+		bodies = append(bodies, string(body))
+
+		status := http.StatusInternalServerError
+		if len(bodies) == 2 {
+			status = http.StatusOK
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	transport := WithRetry()(next)
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/licenses", bytes.NewBufferString("payload"))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected eventual 200, got %d", resp.StatusCode)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Fatalf("attempt %d: expected body %q to be replayed, got %q", i, "payload", body)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package licensing
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/docker/libtrust"
+	"github.com/docker/licensing/lib/errors"
+	"github.com/docker/licensing/model"
+)
+
+// jwsPayload mirrors the claims embedded in the detached JWS payload of an
+// IssuedLicense's Authorization field.
+type jwsPayload struct {
+	Expiration      int64  `json:"expiration"`
+	ScanningEnabled bool   `json:"scanning_enabled"`
+	LicenseType     string `json:"license_type"`
+	Tier            string `json:"tier"`
+	MaxEngines      int    `json:"max_engines"`
+}
+
+// SummarizeLicense builds a SubscriptionDetail out of an already-parsed
+// IssuedLicense, without contacting the licensing backend. It is intended
+// for display-only flows that only have a license on disk.
+func (c *client) SummarizeLicense(license *model.IssuedLicense) (*model.SubscriptionDetail, error) {
+	res, err := c.VerifyLicenseOffline(*license)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to summarize license")
+	}
+
+	state := "active"
+	if res.Expiration <= time.Now().Unix() {
+		state = "expired"
+	}
+
+	return &model.SubscriptionDetail{
+		Expiration: res.Expiration,
+		State:      state,
+		ProductID:  license.Subscription.ProductID,
+		Eusa:       license.Eusa,
+	}, nil
+}
+
+// VerifyLicenseOffline validates the license's detached signature against
+// the client's configured public key and reconstructs a CheckResponse from
+// the signed claims, without calling the licensing backend. This allows
+// display-only flows to work without network access.
+func (c *client) VerifyLicenseOffline(license model.IssuedLicense) (res *model.CheckResponse, err error) {
+	// license.Authorization is the raw JSON produced by
+	// libtrust.JSONSignature.PrettySignature (see Server.IssueLicense), not
+	// a base64-encoded blob, so it's parsed directly.
+	sig, err := libtrust.ParseJWS([]byte(license.Authorization))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse license JWS")
+	}
+
+	if err := sig.Verify(c.publicKey); err != nil {
+		return nil, errors.WithMessage(err, "failed to verify license signature")
+	}
+
+	claims, err := sig.Payload()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read license payload")
+	}
+
+	var p jwsPayload
+	if err := json.Unmarshal(claims, &p); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse license claims")
+	}
+
+	return &model.CheckResponse{
+		Expiration:      p.Expiration,
+		ScanningEnabled: p.ScanningEnabled,
+		LicenseType:     p.LicenseType,
+		Tier:            p.Tier,
+		MaxEngines:      p.MaxEngines,
+	}, nil
+}
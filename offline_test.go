@@ -0,0 +1,127 @@
+package licensing
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/docker/libtrust"
+	"github.com/docker/licensing/model"
+)
+
+type memRepo struct {
+	licenses map[string]*model.IssuedLicense
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{licenses: map[string]*model.IssuedLicense{}}
+}
+
+func (r *memRepo) Insert(ctx context.Context, license *model.IssuedLicense) error {
+	r.licenses[license.ID] = license
+	return nil
+}
+
+func (r *memRepo) List(ctx context.Context, filter model.LicenseFilter) ([]*model.IssuedLicense, error) {
+	var out []*model.IssuedLicense
+	for _, l := range r.licenses {
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func (r *memRepo) Delete(ctx context.Context, id string) error {
+	delete(r.licenses, id)
+	return nil
+}
+
+// newTestServerAndClient generates a fresh keypair and returns a Server
+// and Client configured to sign/verify against it, backed by repo.
+func newTestServerAndClient(t *testing.T, repo LicenseRepository) (*Server, Client) {
+	t.Helper()
+
+	privateKey, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	privatePEM, err := libtrust.MarshalPrivateKeyPEM(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	publicPEM, err := libtrust.MarshalPublicKeyPEM(privateKey.PublicKey())
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+
+	srv, err := NewServer(&Config{PrivateKey: base64.StdEncoding.EncodeToString(privatePEM)}, repo)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	c, err := New(&Config{PublicKey: base64.StdEncoding.EncodeToString(publicPEM)})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return srv, c
+}
+
+// TestIssueLicenseRoundTripsOffline verifies that a license signed by
+// Server.IssueLicense can be verified by client.VerifyLicenseOffline, i.e.
+// that both ends agree on how the Authorization field is encoded.
+func TestIssueLicenseRoundTripsOffline(t *testing.T) {
+	srv, c := newTestServerAndClient(t, newMemRepo())
+
+	issued, err := srv.IssueLicense(context.Background(), &model.LicenseIssueRequest{
+		DockerID:   "docker-id",
+		ProductID:  "docker-ee-standard",
+		Expiration: time.Now().Add(24 * time.Hour).Unix(),
+		Tier:       "standard",
+		MaxEngines: 10,
+	})
+	if err != nil {
+		t.Fatalf("IssueLicense: %v", err)
+	}
+
+	res, err := c.VerifyLicenseOffline(*issued)
+	if err != nil {
+		t.Fatalf("VerifyLicenseOffline: %v", err)
+	}
+
+	if res.Tier != "standard" || res.MaxEngines != 10 {
+		t.Fatalf("unexpected CheckResponse: %+v", res)
+	}
+
+	summary, err := c.SummarizeLicense(issued)
+	if err != nil {
+		t.Fatalf("SummarizeLicense: %v", err)
+	}
+	if summary.State != "active" {
+		t.Fatalf("expected active state for non-expired license, got %q", summary.State)
+	}
+}
+
+// TestSummarizeLicenseExpired verifies that an expired-but-validly-signed
+// license is reported as expired rather than active.
+func TestSummarizeLicenseExpired(t *testing.T) {
+	srv, c := newTestServerAndClient(t, newMemRepo())
+
+	issued, err := srv.IssueLicense(context.Background(), &model.LicenseIssueRequest{
+		DockerID:   "docker-id",
+		ProductID:  "docker-ee-standard",
+		Expiration: time.Now().Add(-24 * time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("IssueLicense: %v", err)
+	}
+
+	summary, err := c.SummarizeLicense(issued)
+	if err != nil {
+		t.Fatalf("SummarizeLicense: %v", err)
+	}
+	if summary.State != "expired" {
+		t.Fatalf("expected expired state, got %q", summary.State)
+	}
+}
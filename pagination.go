@@ -0,0 +1,216 @@
+package licensing
+
+import (
+	"context"
+
+	"github.com/docker/licensing/lib/errors"
+	"github.com/docker/licensing/lib/go-auth/jwt"
+	"github.com/docker/licensing/model"
+)
+
+// defaultPageSize is the page size SubscriptionsIterator and OrgsIterator
+// request from the server. Callers of those two methods cannot choose a
+// different page size.
+const defaultPageSize = 100
+
+// SubscriptionIterator lazily fetches pages of subscriptions, so that
+// callers don't have to hold an entire org's subscriptions in memory at
+// once. Use it as:
+//
+//	it := c.SubscriptionsIterator(ctx, authToken, dockerID, "")
+//	for it.Next() {
+//	    sub := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//	    // handle err
+//	}
+type SubscriptionIterator struct {
+	fetch    func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error)
+	ctx      context.Context
+	pageSize int
+
+	page    []*model.SubscriptionDetail
+	index   int
+	pageNum int
+	done    bool
+	err     error
+	current *model.SubscriptionDetail
+}
+
+// SubscriptionsIterator returns an iterator over dockerID's subscriptions.
+// If productPrefix is non-empty, only subscriptions whose ProductID has
+// that prefix are returned; the filtering happens on the server rather
+// than in the client.
+func (c *client) SubscriptionsIterator(ctx context.Context, authToken, dockerID, productPrefix string) *SubscriptionIterator {
+	filter := map[string]string{"docker_id": dockerID}
+	if productPrefix != "" {
+		filter["product_prefix"] = productPrefix
+	}
+
+	return newSubscriptionIterator(jwt.NewContext(ctx, authToken), defaultPageSize, func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error) {
+		page, err := c.listSubscriptions(ctx, filter, model.PaginationParams{
+			PageSize: pageSize,
+			Page:     pageNum,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, errors.Fields{
+				"dockerID": dockerID,
+			})
+		}
+
+		return page, nil
+	})
+}
+
+func newSubscriptionIterator(ctx context.Context, pageSize int, fetch func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error)) *SubscriptionIterator {
+	return &SubscriptionIterator{
+		fetch:    fetch,
+		ctx:      ctx,
+		pageSize: pageSize,
+	}
+}
+
+// Next advances the iterator, fetching the next page from the server if
+// the current one has been exhausted. It returns false once there is
+// nothing left to iterate, or an error occurred; check Err() to tell the
+// two apart.
+func (it *SubscriptionIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+
+		if err := it.fetchPage(); err != nil {
+			it.err = err
+			return false
+		}
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.current = it.page[it.index]
+	it.index++
+
+	return true
+}
+
+// Value returns the subscription most recently advanced to by Next.
+func (it *SubscriptionIterator) Value() *model.SubscriptionDetail {
+	return it.current
+}
+
+// Err returns the first error encountered while paging, if any.
+func (it *SubscriptionIterator) Err() error {
+	return it.err
+}
+
+func (it *SubscriptionIterator) fetchPage() error {
+	page, err := it.fetch(it.ctx, it.pageNum, it.pageSize)
+	if err != nil {
+		return err
+	}
+
+	it.page = page
+	it.index = 0
+	it.pageNum++
+	it.done = len(page) < it.pageSize
+
+	return nil
+}
+
+// OrgIterator lazily fetches pages of a user's orgs. See
+// SubscriptionIterator for usage.
+type OrgIterator struct {
+	fetch    func(ctx context.Context, pageNum, pageSize int) ([]model.Org, error)
+	ctx      context.Context
+	pageSize int
+
+	page    []model.Org
+	index   int
+	pageNum int
+	done    bool
+	err     error
+	current model.Org
+}
+
+// OrgsIterator returns an iterator over the orgs the authenticated user
+// belongs to.
+func (c *client) OrgsIterator(ctx context.Context, authToken string) *OrgIterator {
+	return newOrgIterator(jwt.NewContext(ctx, authToken), defaultPageSize, func(ctx context.Context, pageNum, pageSize int) ([]model.Org, error) {
+		page, err := c.getUserOrgs(ctx, model.PaginationParams{
+			PageSize: pageSize,
+			Page:     pageNum,
+		})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to get orgs for user")
+		}
+
+		return page, nil
+	})
+}
+
+func newOrgIterator(ctx context.Context, pageSize int, fetch func(ctx context.Context, pageNum, pageSize int) ([]model.Org, error)) *OrgIterator {
+	return &OrgIterator{
+		fetch:    fetch,
+		ctx:      ctx,
+		pageSize: pageSize,
+	}
+}
+
+// Next advances the iterator, fetching the next page from the server if
+// the current one has been exhausted.
+func (it *OrgIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+
+		if err := it.fetchPage(); err != nil {
+			it.err = err
+			return false
+		}
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.current = it.page[it.index]
+	it.index++
+
+	return true
+}
+
+// Value returns the org most recently advanced to by Next.
+func (it *OrgIterator) Value() model.Org {
+	return it.current
+}
+
+// Err returns the first error encountered while paging, if any.
+func (it *OrgIterator) Err() error {
+	return it.err
+}
+
+func (it *OrgIterator) fetchPage() error {
+	page, err := it.fetch(it.ctx, it.pageNum, it.pageSize)
+	if err != nil {
+		return err
+	}
+
+	it.page = page
+	it.index = 0
+	it.pageNum++
+	it.done = len(page) < it.pageSize
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package licensing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/licensing/model"
+)
+
+// fakeSubscriptionPages serves subscriptions out of a slice, split into
+// pages of pageSize, mimicking a paginated backend.
+func fakeSubscriptionPages(all []*model.SubscriptionDetail) func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error) {
+	return func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error) {
+		start := pageNum * pageSize
+		if start > len(all) {
+			start = len(all)
+		}
+		end := start + pageSize
+		if end > len(all) {
+			end = len(all)
+		}
+
+		return all[start:end], nil
+	}
+}
+
+func subIDs(all []*model.SubscriptionDetail) []string {
+	ids := make([]string, len(all))
+	for i, s := range all {
+		ids[i] = s.DockerID
+	}
+	return ids
+}
+
+func drainSubscriptions(it *SubscriptionIterator) ([]*model.SubscriptionDetail, error) {
+	var got []*model.SubscriptionDetail
+	for it.Next() {
+		got = append(got, it.Value())
+	}
+	return got, it.Err()
+}
+
+func TestSubscriptionIteratorMultiPage(t *testing.T) {
+	all := []*model.SubscriptionDetail{
+		{DockerID: "a"}, {DockerID: "b"}, {DockerID: "c"}, {DockerID: "d"}, {DockerID: "e"},
+	}
+
+	it := newSubscriptionIterator(context.Background(), 2, fakeSubscriptionPages(all))
+
+	got, err := drainSubscriptions(it)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := subIDs(all); !equalStrings(subIDs(got), want) {
+		t.Fatalf("got %v, want %v", subIDs(got), want)
+	}
+}
+
+// TestSubscriptionIteratorExactPageBoundary verifies that when the total
+// number of items is an exact multiple of the page size, the iterator
+// still terminates after one extra (empty) fetch rather than looping or
+// stopping early.
+func TestSubscriptionIteratorExactPageBoundary(t *testing.T) {
+	all := []*model.SubscriptionDetail{
+		{DockerID: "a"}, {DockerID: "b"}, {DockerID: "c"}, {DockerID: "d"},
+	}
+
+	fetchCount := 0
+	fetch := fakeSubscriptionPages(all)
+	it := newSubscriptionIterator(context.Background(), 2, func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error) {
+		fetchCount++
+		return fetch(ctx, pageNum, pageSize)
+	})
+
+	got, err := drainSubscriptions(it)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := subIDs(all); !equalStrings(subIDs(got), want) {
+		t.Fatalf("got %v, want %v", subIDs(got), want)
+	}
+
+	// 4 items at page size 2 is an exact boundary: page 0 ("a","b") and
+	// page 1 ("c","d") are both full, so the iterator must fetch a third,
+	// empty page before it can tell it's done.
+	if fetchCount != 3 {
+		t.Fatalf("expected 3 fetches at the exact page boundary, got %d", fetchCount)
+	}
+}
+
+func TestSubscriptionIteratorPropagatesError(t *testing.T) {
+	boom := errWrap("boom")
+
+	it := newSubscriptionIterator(context.Background(), 2, func(ctx context.Context, pageNum, pageSize int) ([]*model.SubscriptionDetail, error) {
+		return nil, boom
+	})
+
+	if it.Next() {
+		t.Fatalf("expected Next() to return false on error")
+	}
+	if it.Err() != boom {
+		t.Fatalf("Err() = %v, want %v", it.Err(), boom)
+	}
+}
+
+func TestOrgIteratorMultiPage(t *testing.T) {
+	all := []model.Org{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	it := newOrgIterator(context.Background(), 2, func(ctx context.Context, pageNum, pageSize int) ([]model.Org, error) {
+		start := pageNum * pageSize
+		if start > len(all) {
+			start = len(all)
+		}
+		end := start + pageSize
+		if end > len(all) {
+			end = len(all)
+		}
+		return all[start:end], nil
+	})
+
+	var got []model.Org
+	for it.Next() {
+		got = append(got, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(all) {
+		t.Fatalf("got %d orgs, want %d", len(got), len(all))
+	}
+	for i, org := range got {
+		if org.ID != all[i].ID {
+			t.Fatalf("org[%d] = %+v, want %+v", i, org, all[i])
+		}
+	}
+}
+
+type errWrap string
+
+func (e errWrap) Error() string { return string(e) }
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}